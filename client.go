@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
@@ -16,35 +17,133 @@ type Client struct {
 	ws        *WSClient
 	geocoding *GeocodingService
 	decoder   *LZWDecoder
+	store     Store
+	hub       *Hub
+	sinks     *SinkFan
+	http      *Server
+	metrics   *Metrics
 	logger    *log.Logger
 }
 
 // Creates a new client with all dependencies
 func NewClient(config *Config) *Client {
-	geocoding := NewGeocodingService(config)
-	decoder := NewLZWDecoder()
-	ws := NewWSClient(config, geocoding, decoder)
+	logger := log.New(os.Stdout, "[Client] ", log.LstdFlags)
+
+	store, err := NewStoreFromConfig(config)
+	if err != nil {
+		logger.Printf("Failed to open store, continuing without persistence: %v", err)
+		store = nil
+	}
+
+	metrics := NewMetrics()
+	geocoding := NewGeocodingService(config, metrics, logger)
+	decoder := NewLZWDecoder(metrics)
+	hub := NewHub()
+	sinks := buildSinks(config, logger, hub, metrics)
+
+	filter, err := NewFilter(config.Filter)
+	if err != nil {
+		logger.Printf("Failed to set up filter, continuing without one: %v", err)
+		filter = nil
+	}
+
+	ws := NewWSClient(config, geocoding, decoder, store, sinks, metrics, filter)
+
+	var server *Server
+	if config.HTTP.Enabled && store != nil {
+		server = NewServer(config, store, ws, hub)
+	}
 
 	return &Client{
 		config:    config,
 		ws:        ws,
 		geocoding: geocoding,
 		decoder:   decoder,
-		logger:    log.New(os.Stdout, "[Client] ", log.LstdFlags),
+		store:     store,
+		hub:       hub,
+		sinks:     sinks,
+		http:      server,
+		metrics:   metrics,
+		logger:    logger,
 	}
 }
 
+// buildSinks constructs the SinkFan for the sinks enabled in config, always
+// including the hub so /stream and /events have data to serve.
+// A sink that fails to initialize (e.g. an unreachable broker) is logged
+// and skipped rather than aborting startup.
+func buildSinks(config *Config, logger *log.Logger, hub *Hub, metrics *Metrics) *SinkFan {
+	sinks := []Sink{hub}
+
+	if config.Sinks.Terminal {
+		sinks = append(sinks, TerminalSink{})
+	}
+
+	if config.Sinks.FilePath != "" {
+		fileSink, err := NewFileSink(config.Sinks.FilePath)
+		if err != nil {
+			logger.Printf("Failed to set up file sink: %v", err)
+		} else {
+			sinks = append(sinks, fileSink)
+		}
+	}
+
+	if config.Sinks.MQTT.Enabled {
+		mqttSink, err := NewMQTTSink(config.Sinks.MQTT.Broker)
+		if err != nil {
+			logger.Printf("Failed to set up MQTT sink: %v", err)
+		} else {
+			sinks = append(sinks, mqttSink)
+		}
+	}
+
+	if config.Sinks.NATS.Enabled {
+		natsSink, err := NewNATSSink(config.Sinks.NATS.URL, config.Sinks.NATS.Subject)
+		if err != nil {
+			logger.Printf("Failed to set up NATS sink: %v", err)
+		} else {
+			sinks = append(sinks, natsSink)
+		}
+	}
+
+	if config.Sinks.Webhook.Enabled {
+		sinks = append(sinks, NewWebhookSink(config.Sinks.Webhook.URL))
+	}
+
+	if config.Sinks.Recorder.Enabled {
+		recorderSink, err := NewRecorderSink(config.Sinks.Recorder)
+		if err != nil {
+			logger.Printf("Failed to set up recorder sink: %v", err)
+		} else {
+			sinks = append(sinks, recorderSink)
+		}
+	}
+
+	return NewSinkFan(logger, metrics, sinks...)
+}
+
 // Starts the client
 func (c *Client) Run() error {
-	if err := c.ws.Connect(); err != nil {
-		return err
+	if c.store != nil {
+		defer c.store.Close()
 	}
-	defer c.ws.Close()
+	defer c.geocoding.Close()
+	defer c.sinks.Close()
 
-	// Send initial message
-	initMessage := []byte(`{"a":111}`)
-	if err := c.ws.SendMessage(initMessage); err != nil {
-		return fmt.Errorf("failed to send initial message: %w", err)
+	if c.http != nil {
+		go func() {
+			if err := c.http.ListenAndServe(); err != nil {
+				c.logger.Printf("HTTP server stopped: %v", err)
+			}
+		}()
+	}
+
+	if c.config.Metrics.Enabled {
+		go func() {
+			if err := c.metrics.ListenAndServe(c.config.Metrics.Addr); err != nil {
+				c.logger.Printf("Metrics server stopped: %v", err)
+			}
+		}()
 	}
 
 	// Create context for graceful shutdown
@@ -55,30 +154,29 @@ func (c *Client) Run() error {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
-	// Channel for read errors
-	readErr := make(chan error, 1)
+	// Channel for the connection supervisor's terminal error
+	done := make(chan error, 1)
 
-	// Start reading messages in a goroutine
 	go func() {
-		readErr <- c.ws.ReadMessages(ctx)
+		done <- c.superviseConnection(ctx)
 	}()
 
 	PrintWelcomeMessage()
 
-	// Wait for either an interrupt signal or read error
+	// Wait for either an interrupt signal or the supervisor giving up
 	select {
-	case err := <-readErr:
+	case err := <-done:
 		if err != nil && err != context.Canceled {
-			return fmt.Errorf("read error: %w", err)
+			return fmt.Errorf("connection supervisor stopped: %w", err)
 		}
 		c.logger.Println("Connection closed by server")
 	case <-interrupt:
 		c.logger.Println("Interrupt received, shutting down...")
-		cancel() // Cancel the context to stop reading
+		cancel() // Cancel the context to stop reconnecting
 
 		// Wait a bit for graceful shutdown
 		select {
-		case <-readErr:
+		case <-done:
 		case <-time.After(5 * time.Second):
 			c.logger.Println("Timeout waiting for graceful shutdown")
 		}
@@ -86,3 +184,92 @@ func (c *Client) Run() error {
 
 	return nil
 }
+
+// maxURLFailures is how many consecutive connection failures against the
+// current server are tolerated before WSClient fails over to the next one.
+const maxURLFailures = 3
+
+// superviseConnection keeps the WebSocket connected for as long as ctx is
+// alive, retrying the current server with exponential backoff and jitter
+// whenever it fails to connect or the read loop drops, rotating to the
+// next configured server after maxURLFailures consecutive failures, and
+// resending the init handshake on every reconnect.
+func (c *Client) superviseConnection(ctx context.Context) error {
+	backoff := c.config.ReconnectInterval
+	consecutiveFailures := 0
+
+	for {
+		c.metrics.ReconnectAttempts.Inc()
+
+		if err := c.ws.Connect(); err != nil {
+			c.logger.Printf("Connect failed: %v", err)
+			consecutiveFailures++
+			if consecutiveFailures >= maxURLFailures {
+				next := c.ws.RotateURL()
+				c.metrics.WSFailovers.Inc()
+				c.logger.Printf("Failing over to %s after %d consecutive failures", next, consecutiveFailures)
+				consecutiveFailures = 0
+			}
+			if !waitBackoff(ctx, &backoff, c.config.MaxReconnectInterval) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		// A successful connect means the backoff and failure count have
+		// done their job; reset them so a long-lived connection doesn't
+		// leave us with a stale large delay or trigger an unwarranted failover.
+		backoff = c.config.ReconnectInterval
+		consecutiveFailures = 0
+
+		initMessage := []byte(`{"a":111}`)
+		if err := c.ws.SendMessage(initMessage); err != nil {
+			c.ws.Close()
+			c.logger.Printf("Failed to send initial message: %v", err)
+			if !waitBackoff(ctx, &backoff, c.config.MaxReconnectInterval) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		pingCtx, stopPing := context.WithCancel(ctx)
+		go c.ws.pingLoop(pingCtx)
+
+		err := c.ws.ReadMessages(ctx)
+		stopPing()
+		c.ws.Close()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			c.logger.Printf("Connection closed by server (reconnecting)")
+		} else {
+			c.logger.Printf("Connection lost: %v (reconnecting)", err)
+		}
+		if !waitBackoff(ctx, &backoff, c.config.MaxReconnectInterval) {
+			return ctx.Err()
+		}
+	}
+}
+
+// waitBackoff sleeps for a jittered backoff interval and doubles *backoff
+// for next time, capped at max. It returns false if ctx is cancelled first.
+func waitBackoff(ctx context.Context, backoff *time.Duration, max time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(jitter(*backoff)):
+	}
+
+	*backoff *= 2
+	if *backoff > max {
+		*backoff = max
+	}
+	return true
+}
+
+// jitter adds up to 50% random jitter to d to avoid thundering-herd reconnects.
+func jitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
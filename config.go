@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// CLI mirrors the subset of Config exposed as flags, parsed with kong.
+// Fields are pointers so LoadConfig can tell "flag not passed" apart from
+// "flag passed with its zero value" when layering over the config file and
+// environment variables.
+type CLI struct {
+	ConfigFile  string `name:"config-file" help:"Path to a YAML or TOML config file." type:"path"`
+	ConfigCheck bool   `name:"config-check" help:"Validate the resolved configuration and exit."`
+	ConfigDump  bool   `name:"config-dump" help:"Print the resolved configuration and exit."`
+
+	URLs             *[]string      `name:"url" help:"Blitzortung WebSocket URL(s), comma-separated. The client fails over to the next one after repeated connection failures."`
+	HandshakeTimeout *time.Duration `help:"WebSocket handshake timeout."`
+	ReadTimeout      *time.Duration `help:"WebSocket read timeout."`
+	WriteTimeout     *time.Duration `help:"WebSocket write timeout."`
+	NominatimURL     *string        `help:"Nominatim reverse geocoding endpoint."`
+	HTTPTimeout      *time.Duration `help:"HTTP client timeout for geocoding requests."`
+
+	ReconnectInterval    *time.Duration `help:"Initial reconnect backoff."`
+	MaxReconnectInterval *time.Duration `help:"Maximum reconnect backoff."`
+	PingInterval         *time.Duration `help:"Keepalive ping interval."`
+
+	GeocoderProvider  *string  `name:"geocoder-provider" help:"Reverse geocoding backend: nominatim, photon, or none."`
+	GeocoderPhotonURL *string  `name:"geocoder-photon-url" help:"Photon instance base URL, used when geocoder-provider is photon."`
+	GeocoderRateLimit *float64 `name:"geocoder-rate-limit" help:"Reverse geocoding requests per second."`
+	GeocoderCachePath *string  `name:"geocoder-cache-path" help:"Path to the on-disk geocode cache; empty disables it."`
+
+	DBType       *string `help:"Storage backend: sqlite or postgres."`
+	DBConnection *string `help:"Storage connection string (sqlite path or postgres DSN)."`
+
+	SinkTerminal   *bool   `name:"sink-terminal" help:"Enable the terminal sink."`
+	SinkFilePath   *string `name:"sink-file-path" help:"NDJSON file sink path."`
+	MQTTEnabled    *bool   `name:"mqtt-enabled" help:"Enable the MQTT sink."`
+	MQTTBroker     *string `name:"mqtt-broker" help:"MQTT broker URL."`
+	NATSEnabled    *bool   `name:"nats-enabled" help:"Enable the NATS sink."`
+	NATSURL        *string `name:"nats-url" help:"NATS server URL."`
+	NATSSubject    *string `name:"nats-subject" help:"NATS publish subject."`
+	WebhookEnabled *bool   `name:"webhook-enabled" help:"Enable the webhook sink."`
+	WebhookURL     *string `name:"webhook-url" help:"Webhook URL."`
+
+	RecorderEnabled     *bool     `name:"recorder-enabled" help:"Enable the recorder sink."`
+	RecorderLogDir      *string   `name:"recorder-log-dir" help:"Directory recorded files are written under."`
+	RecorderFormats     *[]string `name:"recorder-formats" help:"Output formats to record, comma-separated: console, jsonl, csv, gdl90."`
+	RecorderRotateBytes *int64    `name:"recorder-rotate-bytes" help:"Record bytes (before gzip) written to a format's current file before it rolls over; 0 disables rotation."`
+	RecorderGzip        *bool     `name:"recorder-gzip" help:"Gzip-compress recorded files."`
+
+	HTTPEnabled *bool   `name:"http-enabled" help:"Enable the embedded HTTP server."`
+	HTTPAddr    *string `name:"http-addr" help:"HTTP server bind address."`
+
+	MetricsEnabled *bool   `name:"metrics-enabled" help:"Enable the Prometheus metrics endpoint."`
+	MetricsAddr    *string `name:"metrics-addr" help:"Metrics server bind address."`
+}
+
+// LoadConfig resolves a Config by layering defaults, an optional config
+// file, AIRSCRAPER_* environment variables, and CLI flags, in that order
+// (each layer wins over the previous one).
+func LoadConfig(args []string) (*Config, *CLI, error) {
+	var cli CLI
+	parser, err := kong.New(&cli,
+		kong.Name("airscraper"),
+		kong.Description("Lightning strike monitor for the Blitzortung network."),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build CLI parser: %w", err)
+	}
+	if _, err := parser.Parse(args); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	config := DefaultConfig()
+
+	if cli.ConfigFile != "" {
+		if err := loadConfigFile(cli.ConfigFile, config); err != nil {
+			return nil, nil, fmt.Errorf("failed to load config file: %w", err)
+		}
+	}
+
+	applyEnvOverrides(config)
+	cli.applyTo(config)
+
+	return config, &cli, nil
+}
+
+// loadConfigFile merges a YAML (.yaml/.yml) or TOML (.toml) file into
+// config, selected by extension. Fields absent from the file are left
+// untouched since config already holds the defaults.
+func loadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, config); err != nil {
+			return fmt.Errorf("failed to parse TOML: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (want .yaml, .yml or .toml)", ext)
+	}
+
+	return nil
+}
+
+// applyEnvOverrides layers AIRSCRAPER_* environment variables onto config.
+func applyEnvOverrides(config *Config) {
+	if v := os.Getenv("AIRSCRAPER_URL"); v != "" {
+		config.URLs = strings.Split(v, ",")
+	}
+	if v := os.Getenv("AIRSCRAPER_NOMINATIM_URL"); v != "" {
+		config.NominatimURL = v
+	}
+	if v := os.Getenv("AIRSCRAPER_GEOCODER_PROVIDER"); v != "" {
+		config.Geocoder.Provider = v
+	}
+	if v := os.Getenv("AIRSCRAPER_GEOCODER_CACHE_PATH"); v != "" {
+		config.Geocoder.CachePath = v
+	}
+	if v := os.Getenv("AIRSCRAPER_DB_TYPE"); v != "" {
+		config.DBType = v
+	}
+	if v := os.Getenv("AIRSCRAPER_DB_CONNECTION"); v != "" {
+		config.DBConnection = v
+	}
+	if v := os.Getenv("AIRSCRAPER_HTTP_ADDR"); v != "" {
+		config.HTTP.Addr = v
+	}
+	if v := os.Getenv("AIRSCRAPER_METRICS_ADDR"); v != "" {
+		config.Metrics.Addr = v
+	}
+	if v := os.Getenv("AIRSCRAPER_MQTT_BROKER"); v != "" {
+		config.Sinks.MQTT.Broker = v
+	}
+	if v := os.Getenv("AIRSCRAPER_NATS_URL"); v != "" {
+		config.Sinks.NATS.URL = v
+	}
+	if v := os.Getenv("AIRSCRAPER_WEBHOOK_URL"); v != "" {
+		config.Sinks.Webhook.URL = v
+	}
+	if v := os.Getenv("AIRSCRAPER_RECORDER_LOG_DIR"); v != "" {
+		config.Sinks.Recorder.LogDir = v
+	}
+	if v := os.Getenv("AIRSCRAPER_RECORDER_FORMATS"); v != "" {
+		config.Sinks.Recorder.Formats = strings.Split(v, ",")
+	}
+}
+
+// applyTo layers any flags the user actually passed onto config.
+func (cli *CLI) applyTo(config *Config) {
+	if cli.URLs != nil {
+		config.URLs = *cli.URLs
+	}
+	if cli.HandshakeTimeout != nil {
+		config.HandshakeTimeout = *cli.HandshakeTimeout
+	}
+	if cli.ReadTimeout != nil {
+		config.ReadTimeout = *cli.ReadTimeout
+	}
+	if cli.WriteTimeout != nil {
+		config.WriteTimeout = *cli.WriteTimeout
+	}
+	if cli.NominatimURL != nil {
+		config.NominatimURL = *cli.NominatimURL
+	}
+	if cli.HTTPTimeout != nil {
+		config.HTTPTimeout = *cli.HTTPTimeout
+	}
+	if cli.ReconnectInterval != nil {
+		config.ReconnectInterval = *cli.ReconnectInterval
+	}
+	if cli.MaxReconnectInterval != nil {
+		config.MaxReconnectInterval = *cli.MaxReconnectInterval
+	}
+	if cli.PingInterval != nil {
+		config.PingInterval = *cli.PingInterval
+	}
+	if cli.GeocoderProvider != nil {
+		config.Geocoder.Provider = *cli.GeocoderProvider
+	}
+	if cli.GeocoderPhotonURL != nil {
+		config.Geocoder.PhotonURL = *cli.GeocoderPhotonURL
+	}
+	if cli.GeocoderRateLimit != nil {
+		config.Geocoder.RateLimit = *cli.GeocoderRateLimit
+	}
+	if cli.GeocoderCachePath != nil {
+		config.Geocoder.CachePath = *cli.GeocoderCachePath
+	}
+	if cli.DBType != nil {
+		config.DBType = *cli.DBType
+	}
+	if cli.DBConnection != nil {
+		config.DBConnection = *cli.DBConnection
+	}
+	if cli.SinkTerminal != nil {
+		config.Sinks.Terminal = *cli.SinkTerminal
+	}
+	if cli.SinkFilePath != nil {
+		config.Sinks.FilePath = *cli.SinkFilePath
+	}
+	if cli.MQTTEnabled != nil {
+		config.Sinks.MQTT.Enabled = *cli.MQTTEnabled
+	}
+	if cli.MQTTBroker != nil {
+		config.Sinks.MQTT.Broker = *cli.MQTTBroker
+	}
+	if cli.NATSEnabled != nil {
+		config.Sinks.NATS.Enabled = *cli.NATSEnabled
+	}
+	if cli.NATSURL != nil {
+		config.Sinks.NATS.URL = *cli.NATSURL
+	}
+	if cli.NATSSubject != nil {
+		config.Sinks.NATS.Subject = *cli.NATSSubject
+	}
+	if cli.WebhookEnabled != nil {
+		config.Sinks.Webhook.Enabled = *cli.WebhookEnabled
+	}
+	if cli.WebhookURL != nil {
+		config.Sinks.Webhook.URL = *cli.WebhookURL
+	}
+	if cli.RecorderEnabled != nil {
+		config.Sinks.Recorder.Enabled = *cli.RecorderEnabled
+	}
+	if cli.RecorderLogDir != nil {
+		config.Sinks.Recorder.LogDir = *cli.RecorderLogDir
+	}
+	if cli.RecorderFormats != nil {
+		config.Sinks.Recorder.Formats = *cli.RecorderFormats
+	}
+	if cli.RecorderRotateBytes != nil {
+		config.Sinks.Recorder.RotateBytes = *cli.RecorderRotateBytes
+	}
+	if cli.RecorderGzip != nil {
+		config.Sinks.Recorder.Gzip = *cli.RecorderGzip
+	}
+	if cli.HTTPEnabled != nil {
+		config.HTTP.Enabled = *cli.HTTPEnabled
+	}
+	if cli.HTTPAddr != nil {
+		config.HTTP.Addr = *cli.HTTPAddr
+	}
+	if cli.MetricsEnabled != nil {
+		config.Metrics.Enabled = *cli.MetricsEnabled
+	}
+	if cli.MetricsAddr != nil {
+		config.Metrics.Addr = *cli.MetricsAddr
+	}
+}
+
+// Validate performs basic sanity checks on a resolved Config.
+func (c *Config) Validate() error {
+	if len(c.URLs) == 0 {
+		return fmt.Errorf("at least one url must be configured")
+	}
+	for _, u := range c.URLs {
+		if u == "" {
+			return fmt.Errorf("url must not be empty")
+		}
+	}
+	if c.ReconnectInterval <= 0 {
+		return fmt.Errorf("reconnect_interval must be positive")
+	}
+	if c.MaxReconnectInterval < c.ReconnectInterval {
+		return fmt.Errorf("max_reconnect_interval must be >= reconnect_interval")
+	}
+	if c.PingInterval <= 0 {
+		return fmt.Errorf("ping_interval must be positive")
+	}
+	switch c.Geocoder.Provider {
+	case "", "nominatim", "photon", "none", "noop":
+	default:
+		return fmt.Errorf("unsupported geocoder provider %q (want nominatim, photon or none)", c.Geocoder.Provider)
+	}
+	if c.Geocoder.RateLimit <= 0 {
+		return fmt.Errorf("geocoder.rate_limit must be positive")
+	}
+	if c.Sinks.Recorder.Enabled {
+		if c.Sinks.Recorder.LogDir == "" {
+			return fmt.Errorf("sinks.recorder.log_dir must be set when the recorder sink is enabled")
+		}
+		if len(c.Sinks.Recorder.Formats) == 0 {
+			return fmt.Errorf("sinks.recorder.output_formats must list at least one format when the recorder sink is enabled")
+		}
+		for _, format := range c.Sinks.Recorder.Formats {
+			switch format {
+			case "console", "jsonl", "csv", "gdl90":
+			default:
+				return fmt.Errorf("unsupported recorder output format %q (want console, jsonl, csv or gdl90)", format)
+			}
+		}
+		if c.Sinks.Recorder.RotateBytes < 0 {
+			return fmt.Errorf("sinks.recorder.rotate_bytes must not be negative")
+		}
+	}
+	return nil
+}
+
+// Dump renders config as YAML, used by --config-dump.
+func (c *Config) Dump() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return string(data), nil
+}
@@ -8,48 +8,59 @@ import (
 
 // Displays lightning strike information in a formatted way
 func DisplayStrike(strike LightningStrike, location *NominatimResponse) {
-	fmt.Println(strings.Repeat("=", 80))
-	fmt.Println("LIGHTNING STRIKE DETECTED")
-	fmt.Println(strings.Repeat("=", 80))
+	fmt.Print(RenderStrikeBanner(strike, location))
+}
+
+// RenderStrikeBanner renders the same human-readable banner DisplayStrike
+// prints to stdout, for callers (e.g. consoleEmitter) that need it as a
+// string rather than printed directly.
+func RenderStrikeBanner(strike LightningStrike, location *NominatimResponse) string {
+	var b strings.Builder
+
+	fmt.Fprintln(&b, strings.Repeat("=", 80))
+	fmt.Fprintln(&b, "LIGHTNING STRIKE DETECTED")
+	fmt.Fprintln(&b, strings.Repeat("=", 80))
 
 	// Convert timestamp to readable format
 	timestamp := time.Unix(0, strike.Time)
-	fmt.Printf("Time: %s\n", timestamp.Format("2006-01-02 15:04:05.000 MST"))
+	fmt.Fprintf(&b, "Time: %s\n", timestamp.Format("2006-01-02 15:04:05.000 MST"))
 
 	// Location information
-	fmt.Printf("Coordinates: %.6f, %.6f\n", strike.Lat, strike.Lon)
-	fmt.Printf("Location: %s\n", FormatLocation(location))
+	fmt.Fprintf(&b, "Coordinates: %.6f, %.6f\n", strike.Lat, strike.Lon)
+	fmt.Fprintf(&b, "Location: %s\n", FormatLocation(location))
 
 	// Strike characteristics
-	fmt.Printf("Altitude: %d meters\n", strike.Alt)
+	fmt.Fprintf(&b, "Altitude: %d meters\n", strike.Alt)
 	polarity := "Negative"
 	if strike.Pol != 0 {
 		polarity = "Positive"
 	}
-	fmt.Printf("Polarity: %s\n", polarity)
+	fmt.Fprintf(&b, "Polarity: %s\n", polarity)
 
 	// Quality metrics
-	fmt.Printf("Processing delay: %.3f seconds\n", strike.Delay)
-	fmt.Printf("Localization quality (MCG): %d\n", strike.MCG)
-	fmt.Printf("Max distance to stations: %d meters\n", strike.MDS)
+	fmt.Fprintf(&b, "Processing delay: %.3f seconds\n", strike.Delay)
+	fmt.Fprintf(&b, "Localization quality (MCG): %d\n", strike.MCG)
+	fmt.Fprintf(&b, "Max distance to stations: %d meters\n", strike.MDS)
 
 	status := getStatusString(strike.Status)
-	fmt.Printf("Status: %s\n", status)
+	fmt.Fprintf(&b, "Status: %s\n", status)
 
-	fmt.Printf("Region: %d\n", strike.Region)
+	fmt.Fprintf(&b, "Region: %d\n", strike.Region)
 
 	// Detection stations
-	fmt.Printf("Detection stations: %d\n", len(strike.Sig))
+	fmt.Fprintf(&b, "Detection stations: %d\n", len(strike.Sig))
 	if len(strike.Sig) > 0 {
-		fmt.Println("Station details:")
+		fmt.Fprintln(&b, "Station details:")
 		for i, sig := range strike.Sig {
-			fmt.Printf("  [%d] ID: %d, Location: %.6f, %.6f, Alt: %d m, Status: %d\n",
+			fmt.Fprintf(&b, "  [%d] ID: %d, Location: %.6f, %.6f, Alt: %d m, Status: %d\n",
 				i+1, sig.Sta, sig.Lat, sig.Lon, sig.Alt, sig.Status)
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 80))
-	fmt.Println()
+	fmt.Fprintln(&b, strings.Repeat("-", 80))
+	fmt.Fprintln(&b)
+
+	return b.String()
 }
 
 // Formats location information from Nominatim response
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Emitter renders a strike as a single record in a particular output
+// format. consoleEmitter, jsonlEmitter, csvEmitter and gdl90Emitter are the
+// concrete formats, selected via RecorderConfig.Formats and composed by
+// RecorderSink.
+type Emitter interface {
+	// Name identifies the format, and is used as the rotated file's extension.
+	Name() string
+	// Emit renders strike/location as a single record, including its own
+	// line termination or framing where the format needs one.
+	Emit(strike LightningStrike, location *NominatimResponse) ([]byte, error)
+}
+
+// NewEmitter returns the Emitter for name ("console", "jsonl", "csv" or "gdl90").
+func NewEmitter(name string) (Emitter, error) {
+	switch name {
+	case "console":
+		return consoleEmitter{}, nil
+	case "jsonl":
+		return jsonlEmitter{}, nil
+	case "csv":
+		return csvEmitter{}, nil
+	case "gdl90":
+		return gdl90Emitter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (want console, jsonl, csv or gdl90)", name)
+	}
+}
+
+// consoleEmitter renders the same human-readable banner DisplayStrike
+// prints to stdout.
+type consoleEmitter struct{}
+
+func (consoleEmitter) Name() string { return "console" }
+
+func (consoleEmitter) Emit(strike LightningStrike, location *NominatimResponse) ([]byte, error) {
+	return []byte(RenderStrikeBanner(strike, location)), nil
+}
+
+// jsonlEmitter renders a strike as a single JSON-lines record.
+type jsonlEmitter struct{}
+
+func (jsonlEmitter) Name() string { return "jsonl" }
+
+func (jsonlEmitter) Emit(strike LightningStrike, location *NominatimResponse) ([]byte, error) {
+	line, err := json.Marshal(sinkRecord{Strike: strike, Location: location})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal strike: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// csvHeader is the column order csvEmitter writes, matching the /strikes
+// CSV format served by the HTTP server.
+var csvHeader = []string{"time", "lat", "lon", "alt", "polarity", "mcg", "mds", "region", "status", "country_code"}
+
+// csvEmitter renders a strike as a single CSV row, with no header (callers
+// needing one write csvHeader themselves once per file).
+type csvEmitter struct{}
+
+func (csvEmitter) Name() string { return "csv" }
+
+func (csvEmitter) Emit(strike LightningStrike, location *NominatimResponse) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	err := w.Write([]string{
+		strconv.FormatInt(strike.Time, 10),
+		strconv.FormatFloat(strike.Lat, 'f', 6, 64),
+		strconv.FormatFloat(strike.Lon, 'f', 6, 64),
+		strconv.Itoa(strike.Alt),
+		strconv.Itoa(strike.Pol),
+		strconv.Itoa(strike.MCG),
+		strconv.Itoa(strike.MDS),
+		strconv.Itoa(strike.Region),
+		strconv.Itoa(strike.Status),
+		countryCodeOrUnknown(location),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write CSV record: %w", err)
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// gdl90MsgID identifies a strike report frame. GDL90 reserves message IDs
+// per report type; airscraper only ever emits one, so the value is
+// arbitrary but fixed.
+const gdl90MsgID byte = 0x65
+
+// gdl90Flag brackets each frame, as in GDL90's byte-stuffed framing.
+const gdl90Flag byte = 0x7e
+
+// gdl90Emitter frames a strike the way GDL90 frames its reports: a leading
+// flag byte, a message ID, a big-endian payload length, the payload itself
+// (here, the same JSON sinkRecord the other formats use, rather than
+// GDL90's packed binary fields), a CRC-CCITT checksum, and a trailing flag
+// byte. It's "GDL90-inspired" rather than wire-compatible: downstream
+// tooling gets a fixed-size, self-delimiting header suitable for framing a
+// byte stream, without committing to GDL90's specific report encodings.
+type gdl90Emitter struct{}
+
+func (gdl90Emitter) Name() string { return "gdl90" }
+
+func (gdl90Emitter) Emit(strike LightningStrike, location *NominatimResponse) ([]byte, error) {
+	payload, err := json.Marshal(sinkRecord{Strike: strike, Location: location})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal strike: %w", err)
+	}
+	if len(payload) > math.MaxUint16 {
+		return nil, fmt.Errorf("strike record too large for a gdl90 frame: %d bytes", len(payload))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(gdl90Flag)
+	buf.WriteByte(gdl90MsgID)
+	if err := binary.Write(&buf, binary.BigEndian, uint16(len(payload))); err != nil {
+		return nil, fmt.Errorf("failed to write frame length: %w", err)
+	}
+	buf.Write(payload)
+	if err := binary.Write(&buf, binary.BigEndian, crc16CCITT(payload)); err != nil {
+		return nil, fmt.Errorf("failed to write frame checksum: %w", err)
+	}
+	buf.WriteByte(gdl90Flag)
+
+	return buf.Bytes(), nil
+}
+
+// crc16CCITT computes the CRC-CCITT (initial value 0xffff, polynomial
+// 0x1021) checksum GDL90 framing uses.
+func crc16CCITT(data []byte) uint16 {
+	var crc uint16 = 0xffff
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+const earthRadiusKM = 6371.0
+
+// Filter implements the area-of-interest checks configured via
+// FilterConfig. A zero-value Filter (or one built from an empty
+// FilterConfig) admits everything.
+type Filter struct {
+	boxes   []BoundingBox
+	radius  *RadiusConfig
+	polygon [][2]float64 // lon/lat ring, GeoJSON order
+
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// NewFilter builds a Filter from config, loading the GeoJSON polygon file
+// (if any) from disk.
+func NewFilter(config FilterConfig) (*Filter, error) {
+	f := &Filter{
+		boxes:  config.BoundingBoxes,
+		radius: config.Radius,
+	}
+
+	if config.PolygonFile != "" {
+		ring, err := loadGeoJSONPolygon(config.PolygonFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load polygon file: %w", err)
+		}
+		f.polygon = ring
+	}
+
+	if len(config.CountryAllow) > 0 {
+		f.allow = toCountrySet(config.CountryAllow)
+	}
+	if len(config.CountryDeny) > 0 {
+		f.deny = toCountrySet(config.CountryDeny)
+	}
+
+	return f, nil
+}
+
+func toCountrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[strings.ToLower(code)] = true
+	}
+	return set
+}
+
+// AllowBeforeGeocode reports whether a strike at (lat, lon) falls inside
+// the configured bounding boxes, radius or polygon. If none of those are
+// configured, every location is allowed.
+func (f *Filter) AllowBeforeGeocode(lat, lon float64) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.boxes) == 0 && f.radius == nil && f.polygon == nil {
+		return true
+	}
+
+	for _, box := range f.boxes {
+		if lat >= box.MinLat && lat <= box.MaxLat && lon >= box.MinLon && lon <= box.MaxLon {
+			return true
+		}
+	}
+
+	if f.radius != nil && haversineKM(lat, lon, f.radius.CenterLat, f.radius.CenterLon) <= f.radius.RadiusKM {
+		return true
+	}
+
+	if f.polygon != nil && pointInPolygon(lon, lat, f.polygon) {
+		return true
+	}
+
+	return false
+}
+
+// AllowAfterGeocode reports whether a strike's reverse-geocoded country
+// code passes the allow/deny lists. If neither list is configured, every
+// country is allowed.
+func (f *Filter) AllowAfterGeocode(location *NominatimResponse) bool {
+	if f == nil || (f.allow == nil && f.deny == nil) {
+		return true
+	}
+
+	cc := strings.ToLower(countryCodeOrUnknown(location))
+
+	if f.allow != nil && !f.allow[cc] {
+		return false
+	}
+	if f.deny != nil && f.deny[cc] {
+		return false
+	}
+	return true
+}
+
+// haversineKM returns the great-circle distance between two lat/lon points
+// in kilometers.
+func haversineKM(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusKM * c
+}
+
+// pointInPolygon tests containment of (x, y) in ring using the standard
+// ray-casting algorithm. ring is a closed or open list of [lon, lat] pairs.
+func pointInPolygon(x, y float64, ring [][2]float64) bool {
+	inside := false
+	n := len(ring)
+
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		intersects := (yi > y) != (yj > y) &&
+			x < (xj-xi)*(y-yi)/(yj-yi)+xi
+		if intersects {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// geoJSONGeometry is the minimal subset of GeoJSON needed to extract a
+// single ring out of a Polygon/MultiPolygon geometry or feature.
+type geoJSONGeometry struct {
+	Type        string           `json:"type"`
+	Coordinates json.RawMessage  `json:"coordinates"`
+	Geometry    *geoJSONGeometry `json:"geometry"`
+}
+
+// loadGeoJSONPolygon reads a GeoJSON file and returns the outer ring of its
+// first Polygon (or the first polygon of a MultiPolygon/Feature).
+func loadGeoJSONPolygon(path string) ([][2]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var doc geoJSONGeometry
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	geom := &doc
+	if geom.Geometry != nil {
+		geom = geom.Geometry
+	}
+
+	switch geom.Type {
+	case "Polygon":
+		var rings [][][2]float64
+		if err := json.Unmarshal(geom.Coordinates, &rings); err != nil {
+			return nil, fmt.Errorf("failed to parse Polygon coordinates: %w", err)
+		}
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("polygon has no rings")
+		}
+		return rings[0], nil
+	case "MultiPolygon":
+		var polygons [][][][2]float64
+		if err := json.Unmarshal(geom.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("failed to parse MultiPolygon coordinates: %w", err)
+		}
+		if len(polygons) == 0 || len(polygons[0]) == 0 {
+			return nil, fmt.Errorf("multipolygon has no rings")
+		}
+		return polygons[0][0], nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON geometry type %q (want Polygon or MultiPolygon)", geom.Type)
+	}
+}
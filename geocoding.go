@@ -1,37 +1,46 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"time"
+	"strings"
+
+	"go.etcd.io/bbolt"
+	"golang.org/x/time/rate"
 )
 
-// Handles reverse geocoding operations
-type GeocodingService struct {
+// Geocoder resolves a lat/lon pair to a human-readable location.
+// nominatimGeocoder, photonGeocoder and noopGeocoder are the concrete
+// backends, selected via Config.Geocoder.Provider and composed with rate
+// limiting and caching by NewGeocoder.
+type Geocoder interface {
+	ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error)
+}
+
+// nominatimGeocoder resolves locations against the OpenStreetMap Nominatim API.
+type nominatimGeocoder struct {
 	client *http.Client
-	config *Config
+	url    string
 }
 
-// Creates a new geocoding service
-func NewGeocodingService(config *Config) *GeocodingService {
-	return &GeocodingService{
-		client: &http.Client{
-			Timeout: config.HTTPTimeout,
-		},
-		config: config,
+func newNominatimGeocoder(config *Config) *nominatimGeocoder {
+	return &nominatimGeocoder{
+		client: &http.Client{Timeout: config.HTTPTimeout},
+		url:    config.NominatimURL,
 	}
 }
 
-// Performs reverse geocoding using Nominatim API
-func (g *GeocodingService) ReverseGeocode(lat, lon float64) (*NominatimResponse, error) {
+func (g *nominatimGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
 	url := fmt.Sprintf(
 		"%s?format=json&lat=%f&lon=%f&zoom=18&addressdetails=1",
-		g.config.NominatimURL, lat, lon,
+		g.url, lat, lon,
 	)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -59,15 +68,292 @@ func (g *GeocodingService) ReverseGeocode(lat, lon float64) (*NominatimResponse,
 	return &result, nil
 }
 
-// Performs reverse geocoding with rate limiting
-func (g *GeocodingService) ReverseGeocodeWithRateLimit(lat, lon float64) (*NominatimResponse, error) {
-	location, err := g.ReverseGeocode(lat, lon)
+// photonGeocoder resolves locations against a Photon (Komoot) instance,
+// translating its GeoJSON response into the shared NominatimResponse shape.
+type photonGeocoder struct {
+	client *http.Client
+	url    string
+}
+
+func newPhotonGeocoder(config *Config) *photonGeocoder {
+	return &photonGeocoder{
+		client: &http.Client{Timeout: config.HTTPTimeout},
+		url:    config.Geocoder.PhotonURL,
+	}
+}
+
+// photonResponse is the subset of Photon's GeoJSON /reverse response that
+// maps onto NominatimResponse.
+type photonResponse struct {
+	Features []struct {
+		Properties struct {
+			CountryCode string `json:"countrycode"`
+			State       string `json:"state"`
+			City        string `json:"city"`
+			Street      string `json:"street"`
+			Name        string `json:"name"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func (g *photonGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
+	url := fmt.Sprintf("%s/reverse?lat=%f&lon=%f", g.url, lat, lon)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	var parsed photonResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(parsed.Features) == 0 {
+		return &NominatimResponse{}, nil
+	}
+
+	props := parsed.Features[0].Properties
+	result := &NominatimResponse{DisplayName: props.Name}
+	result.Address.CountryCode = strings.ToLower(props.CountryCode)
+	result.Address.State = props.State
+	result.Address.City = props.City
+	result.Address.Road = props.Street
+
+	return result, nil
+}
+
+// noopGeocoder never resolves a location, letting high-throughput
+// deployments skip reverse geocoding, and the rate limit that comes with
+// it, entirely.
+type noopGeocoder struct{}
+
+func (noopGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
+	return nil, nil
+}
+
+// rateLimitedGeocoder throttles calls to next to respect the provider's
+// published rate limit (Nominatim's usage policy allows 1 req/s).
+type rateLimitedGeocoder struct {
+	next    Geocoder
+	limiter *rate.Limiter
+}
+
+func newRateLimitedGeocoder(next Geocoder, requestsPerSecond float64) *rateLimitedGeocoder {
+	return &rateLimitedGeocoder{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+	}
+}
+
+func (g *rateLimitedGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
+	if err := g.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter: %w", err)
+	}
+	return g.next.ReverseGeocode(ctx, lat, lon)
+}
+
+// geocodeCacheBucket is the sole bbolt bucket cachedGeocoder reads and writes.
+var geocodeCacheBucket = []byte("geocode_cache")
+
+// cachedGeocoder decorates a Geocoder with an on-disk cache keyed on a
+// coarse geohash, so restarting the process doesn't refetch a location for
+// a strike near one already seen. It also drives the geocode cache
+// hit/miss metrics, since it's the only place those decisions are made.
+type cachedGeocoder struct {
+	next      Geocoder
+	db        *bbolt.DB
+	precision int
+	metrics   *Metrics
+}
+
+// newCachedGeocoder opens (creating if necessary) the bbolt cache at path.
+func newCachedGeocoder(next Geocoder, path string, precision int, metrics *Metrics) (*cachedGeocoder, error) {
+	db, err := bbolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geocode cache: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(geocodeCacheBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize geocode cache: %w", err)
+	}
+
+	return &cachedGeocoder{next: next, db: db, precision: precision, metrics: metrics}, nil
+}
+
+func (c *cachedGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
+	key := []byte(encodeGeohash(lat, lon, c.precision))
+
+	var cached NominatimResponse
+	found := false
+	err := c.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(geocodeCacheBucket).Get(key)
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &cached)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read geocode cache: %w", err)
+	}
+	if found {
+		c.metrics.GeocodeCacheHits.Inc()
+		return &cached, nil
+	}
+	c.metrics.GeocodeCacheMiss.Inc()
+
+	location, err := c.next.ReverseGeocode(ctx, lat, lon)
 	if err != nil {
 		return nil, err
 	}
+	if location == nil {
+		return nil, nil
+	}
+
+	if raw, err := json.Marshal(location); err == nil {
+		_ = c.db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket(geocodeCacheBucket).Put(key, raw)
+		})
+	}
+
+	return location, nil
+}
+
+func (c *cachedGeocoder) Close() error {
+	return c.db.Close()
+}
+
+// geohashAlphabet is the base32 alphabet used by the standard geohash
+// algorithm (it omits "a", "i", "l" and "o" to avoid confusion with "0",
+// "1" when read aloud).
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// encodeGeohash returns the first precision characters of (lat, lon)'s
+// geohash. This is deliberately coarse: 5 characters is roughly a 5km by
+// 5km cell, 6 roughly 1km by 0.5km, which is enough to let nearby strikes
+// share a cachedGeocoder entry without needing exact coordinates to match.
+func encodeGeohash(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+	bit, ch := 0, 0
+	evenBit := true
+
+	for hash.Len() < precision {
+		if evenBit {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				ch |= 1 << (4 - bit)
+				lonRange[0] = mid
+			} else {
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		evenBit = !evenBit
+
+		if bit < 4 {
+			bit++
+		} else {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return hash.String()
+}
 
-	// Add delay to respect Nominatim rate limits
-	time.Sleep(1 * time.Second)
+// NewGeocoder builds the Geocoder selected by config.Geocoder.Provider,
+// wrapped in rate limiting and (unless disabled) an on-disk cache. An
+// unsupported provider or a cache that fails to open is logged and
+// degrades to the default rather than aborting startup.
+func NewGeocoder(config *Config, metrics *Metrics, logger *log.Logger) Geocoder {
+	var backend Geocoder
+	switch config.Geocoder.Provider {
+	case "", "nominatim":
+		backend = newNominatimGeocoder(config)
+	case "photon":
+		backend = newPhotonGeocoder(config)
+	case "none", "noop":
+		return noopGeocoder{}
+	default:
+		logger.Printf("Unknown geocoder provider %q, falling back to nominatim", config.Geocoder.Provider)
+		backend = newNominatimGeocoder(config)
+	}
+
+	limited := newRateLimitedGeocoder(backend, config.Geocoder.RateLimit)
+
+	if config.Geocoder.CachePath == "" {
+		return limited
+	}
 
+	cached, err := newCachedGeocoder(limited, config.Geocoder.CachePath, config.Geocoder.CacheGeohashPrecision, metrics)
+	if err != nil {
+		logger.Printf("Failed to set up geocode cache, continuing without one: %v", err)
+		return limited
+	}
+
+	return cached
+}
+
+// GeocodingService is the strike-processing entry point for reverse
+// geocoding; it owns no network or caching policy itself, delegating all
+// of it to the Geocoder built by NewGeocoder.
+type GeocodingService struct {
+	geocoder Geocoder
+	metrics  *Metrics
+}
+
+// NewGeocodingService builds a GeocodingService around the Geocoder
+// selected by config.
+func NewGeocodingService(config *Config, metrics *Metrics, logger *log.Logger) *GeocodingService {
+	return &GeocodingService{
+		geocoder: NewGeocoder(config, metrics, logger),
+		metrics:  metrics,
+	}
+}
+
+// ReverseGeocodeWithRateLimit resolves a strike's location. The name is
+// kept from before the rate limiter moved into the Geocoder chain, since
+// callers only care that it respects the provider's rate limit, not where
+// that's enforced.
+func (g *GeocodingService) ReverseGeocodeWithRateLimit(ctx context.Context, lat, lon float64) (*NominatimResponse, error) {
+	location, err := g.geocoder.ReverseGeocode(ctx, lat, lon)
+	if err != nil {
+		g.metrics.GeocodeFailures.Inc()
+		return nil, err
+	}
 	return location, nil
 }
+
+// Close releases resources held by the underlying geocoder, such as an
+// open cache file, if it holds any.
+func (g *GeocodingService) Close() error {
+	if closer, ok := g.geocoder.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
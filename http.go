@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server exposes live and historical strikes over HTTP.
+type Server struct {
+	config *Config
+	store  Store
+	ws     *WSClient
+	hub    *Hub
+	logger *log.Logger
+}
+
+// NewServer builds an HTTP server backed by store for history and hub for
+// the live feed.
+func NewServer(config *Config, store Store, ws *WSClient, hub *Hub) *Server {
+	return &Server{
+		config: config,
+		store:  store,
+		ws:     ws,
+		hub:    hub,
+		logger: log.New(os.Stdout, "[HTTP] ", log.LstdFlags),
+	}
+}
+
+// Handler returns the server's routes, useful for testing without binding a port.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/strikes", s.handleStrikes)
+	mux.HandleFunc("/stream", s.handleHubStream)
+	mux.HandleFunc("/events", s.handleHubEvents)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	return mux
+}
+
+// ListenAndServe starts serving on config.HTTP.Addr.
+func (s *Server) ListenAndServe() error {
+	s.logger.Printf("Listening on %s", s.config.HTTP.Addr)
+	return http.ListenAndServe(s.config.HTTP.Addr, s.Handler())
+}
+
+// boundingBox is a simple lat/lon rectangle used to filter /strikes and /stream.
+type boundingBox struct {
+	minLat, minLon, maxLat, maxLon float64
+}
+
+func (b *boundingBox) contains(lat, lon float64) bool {
+	return lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon
+}
+
+func parseBoundingBox(raw string) (*boundingBox, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be minLat,minLon,maxLat,maxLon")
+	}
+
+	vals := make([]float64, 4)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bbox value %q: %w", p, err)
+		}
+		vals[i] = v
+	}
+
+	return &boundingBox{minLat: vals[0], minLon: vals[1], maxLat: vals[2], maxLon: vals[3]}, nil
+}
+
+// handleStrikes returns recent strikes in the format selected by ?format=
+// or the Accept header: json (default), ndjson, csv, plain, or geojson.
+// Supports ?since= (RFC3339, default 1h ago), ?bbox=, ?maxStatus= and ?cc=
+// (country code, post-query since it depends on the resolved location).
+func (s *Server) handleStrikes(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-1 * time.Hour)
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	bbox, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var maxStatus *int
+	if raw := r.URL.Query().Get("maxStatus"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid maxStatus: %v", err), http.StatusBadRequest)
+			return
+		}
+		maxStatus = &v
+	}
+
+	strikes, err := s.store.Query(r.Context(), StrikeFilter{Since: since, BBox: bbox, MaxStatus: maxStatus})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to query strikes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if country := strings.ToLower(r.URL.Query().Get("cc")); country != "" {
+		filtered := strikes[:0]
+		for _, strike := range strikes {
+			if strike.Location != nil && strings.ToLower(strike.Location.Address.CountryCode) == country {
+				filtered = append(filtered, strike)
+			}
+		}
+		strikes = filtered
+	}
+
+	switch responseFormat(r) {
+	case "ndjson":
+		writeNDJSON(w, strikes)
+	case "csv":
+		writeCSV(w, strikes)
+	case "plain":
+		writePlain(w, strikes)
+	case "geojson":
+		writeGeoJSON(w, strikes)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(strikes)
+	}
+}
+
+// responseFormat resolves the desired output format from ?format= or Accept.
+func responseFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "ndjson"):
+		return "ndjson"
+	case strings.Contains(accept, "csv"):
+		return "csv"
+	case strings.Contains(accept, "geo+json"):
+		return "geojson"
+	case strings.Contains(accept, "text/plain"):
+		return "plain"
+	default:
+		return "json"
+	}
+}
+
+func writeNDJSON(w http.ResponseWriter, strikes []StoredStrike) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, strike := range strikes {
+		enc.Encode(strike)
+	}
+}
+
+func writeCSV(w http.ResponseWriter, strikes []StoredStrike) {
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	cw.Write([]string{"time", "lat", "lon", "alt", "polarity", "mcg", "mds", "region", "status", "country_code"})
+	for _, strike := range strikes {
+		cw.Write([]string{
+			strconv.FormatInt(strike.Time, 10),
+			strconv.FormatFloat(strike.Lat, 'f', 6, 64),
+			strconv.FormatFloat(strike.Lon, 'f', 6, 64),
+			strconv.Itoa(strike.Alt),
+			strconv.Itoa(strike.Pol),
+			strconv.Itoa(strike.MCG),
+			strconv.Itoa(strike.MDS),
+			strconv.Itoa(strike.Region),
+			strconv.Itoa(strike.Status),
+			countryCodeOrUnknown(strike.Location),
+		})
+	}
+}
+
+func writePlain(w http.ResponseWriter, strikes []StoredStrike) {
+	w.Header().Set("Content-Type", "text/plain")
+	for _, strike := range strikes {
+		fmt.Fprintf(w, "%s %.6f,%.6f alt=%dm mcg=%d mds=%d status=%d\n",
+			time.Unix(0, strike.Time).Format(time.RFC3339), strike.Lat, strike.Lon,
+			strike.Alt, strike.MCG, strike.MDS, strike.Status)
+	}
+}
+
+// geoJSONFeatureCollection is the minimal shape Leaflet/Mapbox expect.
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func writeGeoJSON(w http.ResponseWriter, strikes []StoredStrike) {
+	w.Header().Set("Content-Type", "application/geo+json")
+
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection"}
+	for _, strike := range strikes {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONPoint{Type: "Point", Coordinates: []float64{strike.Lon, strike.Lat}},
+			Properties: map[string]interface{}{
+				"time":     strike.Time,
+				"alt":      strike.Alt,
+				"polarity": strike.Pol,
+				"mcg":      strike.MCG,
+				"mds":      strike.MDS,
+				"status":   strike.Status,
+			},
+		})
+	}
+
+	json.NewEncoder(w).Encode(collection)
+}
+
+// handleHealthz reports WebSocket connection state and time since the last
+// observed strike.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	health := struct {
+		Connected        bool    `json:"connected"`
+		SecondsSinceLast float64 `json:"seconds_since_last_strike"`
+	}{
+		Connected:        s.ws.Connected(),
+		SecondsSinceLast: time.Since(s.ws.LastStrikeAt()).Seconds(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
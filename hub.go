@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// hubQueueSize bounds each subscriber's outbound queue. When full, the
+// oldest queued message is dropped to make room for the newest one rather
+// than blocking the fan-out or growing without bound.
+const hubQueueSize = 32
+
+// hubFilter narrows which strikes a subscriber receives, derived from the
+// bbox, minMCG and country query params on /stream and /events.
+type hubFilter struct {
+	bbox    *boundingBox
+	minMCG  int
+	country string
+}
+
+func (f hubFilter) matches(strike LightningStrike, location *NominatimResponse) bool {
+	if f.bbox != nil && !f.bbox.contains(strike.Lat, strike.Lon) {
+		return false
+	}
+	if strike.MCG < f.minMCG {
+		return false
+	}
+	if f.country != "" && strings.ToLower(countryCodeOrUnknown(location)) != f.country {
+		return false
+	}
+	return true
+}
+
+func parseHubFilter(r *http.Request) (hubFilter, error) {
+	var filter hubFilter
+
+	bbox, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		return filter, err
+	}
+	filter.bbox = bbox
+
+	if raw := r.URL.Query().Get("minMCG"); raw != "" {
+		minMCG, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid minMCG: %w", err)
+		}
+		filter.minMCG = minMCG
+	}
+
+	filter.country = strings.ToLower(r.URL.Query().Get("country"))
+
+	return filter, nil
+}
+
+// hubSubscriber is a single /stream or /events client.
+type hubSubscriber struct {
+	ch     chan []byte
+	filter hubFilter
+}
+
+// Hub implements Sink and re-broadcasts enriched strikes to subscribers of
+// ws://host/stream (JSON lines over a WebSocket) and GET /events (SSE),
+// each optionally filtered by bbox, minMCG and/or country.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*hubSubscriber]struct{}
+	upgrader    websocket.Upgrader
+}
+
+// NewHub returns an empty Hub.
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[*hubSubscriber]struct{}),
+		upgrader: websocket.Upgrader{
+			// Airscraper is a self-hosted broadcast server, not a
+			// browser-facing site; any origin may subscribe.
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Name identifies this sink for logging and metrics.
+func (h *Hub) Name() string { return "hub" }
+
+// Subscribe registers a new client matching filter and returns it.
+func (h *Hub) Subscribe(filter hubFilter) *hubSubscriber {
+	sub := &hubSubscriber{ch: make(chan []byte, hubQueueSize), filter: filter}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes and closes a previously subscribed client.
+func (h *Hub) Unsubscribe(sub *hubSubscriber) {
+	h.mu.Lock()
+	delete(h.subscribers, sub)
+	h.mu.Unlock()
+
+	close(sub.ch)
+}
+
+// Emit publishes the strike to every subscriber whose filter matches it.
+// A subscriber whose queue is full has its oldest message dropped to make
+// room, so a slow client falls behind rather than blocking the fan-out.
+func (h *Hub) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	line, err := json.Marshal(sinkRecord{Strike: strike, Location: location})
+	if err != nil {
+		return fmt.Errorf("failed to marshal strike: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.matches(strike, location) {
+			continue
+		}
+
+		select {
+		case sub.ch <- line:
+			continue
+		default:
+		}
+
+		// Queue full: drop the oldest message and retry once.
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- line:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// handleHubStream upgrades the request to a WebSocket and writes each
+// matching strike as a JSON line until the client disconnects.
+func (s *Server) handleHubStream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHubFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := s.hub.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Printf("Failed to upgrade /stream connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := s.hub.Subscribe(filter)
+	defer s.hub.Unsubscribe(sub)
+
+	// Drain and discard whatever the client sends so control frames (close,
+	// ping/pong) are handled and the connection's closure is detected.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, line); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleHubEvents serves matching strikes as a Server-Sent Events stream.
+func (s *Server) handleHubEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseHubFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	sub := s.hub.Subscribe(filter)
+	defer s.hub.Unsubscribe(sub)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		}
+	}
+}
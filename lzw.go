@@ -1,61 +1,107 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
 
 // Handles LZW decompression
-type LZWDecoder struct{}
+type LZWDecoder struct {
+	metrics *Metrics
+}
 
 // Creates a new LZW decoder
-func NewLZWDecoder() *LZWDecoder {
-	return &LZWDecoder{}
+func NewLZWDecoder(metrics *Metrics) *LZWDecoder {
+	return &LZWDecoder{metrics: metrics}
+}
+
+// decoderState holds the dictionary and output buffers for a single Decode
+// call so they can be reused across calls via decoderStatePool instead of
+// being allocated fresh on every websocket frame.
+type decoderState struct {
+	dict   [][]byte
+	output []byte
+}
+
+var decoderStatePool = sync.Pool{
+	New: func() any {
+		ds := &decoderState{
+			dict:   make([][]byte, 256, 1024),
+			output: make([]byte, 0, 1024),
+		}
+		for i := 0; i < 256; i++ {
+			ds.dict[i] = []byte{byte(i)}
+		}
+		return ds
+	},
 }
 
-// Decodes LZW compressed data
+// getDecoderState checks out a decoderState with the dictionary truncated
+// back to its seeded 0-255 entries and the output buffer emptied.
+func getDecoderState() *decoderState {
+	ds := decoderStatePool.Get().(*decoderState)
+	ds.dict = ds.dict[:256]
+	ds.output = ds.output[:0]
+	return ds
+}
+
+func putDecoderState(ds *decoderState) {
+	decoderStatePool.Put(ds)
+}
+
+// Decodes LZW compressed data. The input is a sequence of codepoints, one
+// rune per code; codes 0-255 are literal bytes and codes 256+ are assigned
+// densely and monotonically as the dictionary grows.
 func (d *LZWDecoder) Decode(inputBytes []byte) ([]byte, error) {
 	if len(inputBytes) == 0 {
 		return []byte{}, nil
 	}
 
-	input := string(inputBytes)
-	data := []rune(input)
+	ds := getDecoderState()
+	defer putDecoderState(ds)
 
-	// Initialize the dictionary: codes 0-255 (ASCII characters)
-	dict := make(map[int]string, 256)
-	for i := range 256 {
-		dict[i] = string(rune(i))
-	}
+	firstRune, size := utf8.DecodeRune(inputBytes)
+	inputBytes = inputBytes[size:]
 
-	var result []byte
-	prev := string(data[0])
-	result = append(result, byte(data[0]))
-	code := 256
+	firstCode := int(firstRune)
+	if firstCode >= len(ds.dict) {
+		d.metrics.LZWDecodeErrors.Inc()
+		return nil, fmt.Errorf("invalid LZW data: unknown initial code %d", firstCode)
+	}
+	prev := ds.dict[firstCode]
+	ds.output = append(ds.output, prev...)
 
-	for i := 1; i < len(data); i++ {
-		currCode := int(data[i])
-		var entry string
+	for len(inputBytes) > 0 {
+		currRune, size := utf8.DecodeRune(inputBytes)
+		inputBytes = inputBytes[size:]
+		currCode := int(currRune)
 
-		if currCode < 256 {
-			entry = string(rune(currCode))
-		} else if val, exists := dict[currCode]; exists {
-			entry = val
+		var entry []byte
+		if currCode < len(ds.dict) {
+			entry = ds.dict[currCode]
 		} else {
-			// Special case: entry not yet in the dictionary
+			// Special case: code not yet in the dictionary, i.e. the
+			// sequence currently being built refers to itself.
 			if len(prev) == 0 {
+				d.metrics.LZWDecodeErrors.Inc()
 				return nil, fmt.Errorf("invalid LZW data: empty previous string")
 			}
-			entry = prev + string(prev[0])
+			entry = append(append([]byte(nil), prev...), prev[0])
 		}
 
-		// Add to the decompressed string
-		result = append(result, []byte(entry)...)
+		ds.output = append(ds.output, entry...)
 
-		// Update the dictionary
 		if len(entry) > 0 {
-			dict[code] = prev + string(entry[0])
-			code++
+			newEntry := make([]byte, len(prev)+1)
+			copy(newEntry, prev)
+			newEntry[len(prev)] = entry[0]
+			ds.dict = append(ds.dict, newEntry)
 		}
 		prev = entry
 	}
 
+	result := make([]byte, len(ds.output))
+	copy(result, ds.output)
 	return result, nil
 }
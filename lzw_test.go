@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+// encodeLZWForTest is a minimal encoder mirroring the decoder's dictionary
+// rules, used only to build the encoded input for TestDecode and BenchmarkDecode.
+func encodeLZWForTest(input []byte) []rune {
+	dict := make(map[string]int, 256)
+	for i := 0; i < 256; i++ {
+		dict[string(rune(i))] = i
+	}
+	next := 256
+
+	var codes []rune
+	w := ""
+	for _, b := range input {
+		wc := w + string(rune(b))
+		if _, ok := dict[wc]; ok {
+			w = wc
+			continue
+		}
+		codes = append(codes, rune(dict[w]))
+		dict[wc] = next
+		next++
+		w = string(rune(b))
+	}
+	if w != "" {
+		codes = append(codes, rune(dict[w]))
+	}
+	return codes
+}
+
+func runesToBytes(codes []rune) []byte {
+	out := make([]byte, 0, len(codes)*3)
+	for _, r := range codes {
+		out = append(out, []byte(string(r))...)
+	}
+	return out
+}
+
+// benchCorpus approximates a real Blitzortung strike frame.
+var benchCorpus = []byte(`{"time":1690000000123456789,"lat":48.2082,"lon":16.3738,"alt":0,"pol":0,"mds":12,"mcg":34,"status":1,"region":4,"sig":[{"time":0,"lat":48.21,"lon":16.37,"alt":0,"sta":123,"status":1},{"time":120,"lat":48.19,"lon":16.38,"alt":0,"sta":456,"status":1}]}`)
+
+// benchMetrics is built once at package load instead of inside
+// BenchmarkDecode: the testing package re-invokes a benchmark function body
+// several times while calibrating b.N, and NewMetrics registers collectors
+// against the global Prometheus registry, which panics on the second call.
+var benchMetrics = NewMetrics()
+
+func TestDecode(t *testing.T) {
+	decoder := NewLZWDecoder(benchMetrics)
+	encoded := runesToBytes(encodeLZWForTest(benchCorpus))
+
+	got, err := decoder.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if string(got) != string(benchCorpus) {
+		t.Fatalf("Decode = %q, want %q", got, benchCorpus)
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	decoder := NewLZWDecoder(benchMetrics)
+	encoded := runesToBytes(encodeLZWForTest(benchCorpus))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decoder.Decode(encoded); err != nil {
+			b.Fatalf("Decode failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds every Prometheus collector airscraper exports.
+type Metrics struct {
+	StrikesTotal       *prometheus.CounterVec
+	StrikeDelay        prometheus.Histogram
+	StrikeMCG          prometheus.Histogram
+	ProcessingDuration prometheus.Histogram
+	WSConnected        prometheus.Gauge
+	LastStrikeTime     prometheus.Gauge
+	LZWDecodeErrors    prometheus.Counter
+	GeocodeCacheHits   prometheus.Counter
+	GeocodeCacheMiss   prometheus.Counter
+	GeocodeFailures    prometheus.Counter
+	ReconnectAttempts  prometheus.Counter
+	WSFailovers        prometheus.Counter
+	SinkEmitFailures   *prometheus.CounterVec
+
+	FilteredBeforeGeocode prometheus.Counter
+	FilteredAfterGeocode  prometheus.Counter
+
+	logger *log.Logger
+}
+
+// NewMetrics registers and returns the collectors used throughout airscraper.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		StrikesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "airscraper_strikes_total",
+			Help: "Number of lightning strikes observed.",
+		}, []string{"country_code", "polarity", "status"}),
+
+		StrikeDelay: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "airscraper_strike_delay_seconds",
+			Help:    "Blitzortung-reported processing delay of observed strikes.",
+			Buckets: prometheus.DefBuckets,
+		}),
+
+		StrikeMCG: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "airscraper_strike_mcg",
+			Help:    "Localization quality (MCG) of observed strikes.",
+			Buckets: prometheus.LinearBuckets(0, 10, 10),
+		}),
+
+		ProcessingDuration: promauto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "airscraper_processing_duration_seconds",
+			Help:    "Time spent processing a single strike message, from LZW decode through sink fan-out.",
+			Buckets: prometheus.DefBuckets,
+		}),
+
+		WSConnected: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "airscraper_ws_connected",
+			Help: "Whether the Blitzortung WebSocket is currently connected (1) or not (0).",
+		}),
+
+		LastStrikeTime: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "airscraper_last_strike_timestamp_seconds",
+			Help: "Unix timestamp of the most recently observed strike.",
+		}),
+
+		LZWDecodeErrors: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_lzw_decode_errors_total",
+			Help: "Number of LZW frames that failed to decode.",
+		}),
+
+		GeocodeCacheHits: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_geocode_cache_hits_total",
+			Help: "Number of reverse geocode lookups served from the cache.",
+		}),
+
+		GeocodeCacheMiss: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_geocode_cache_misses_total",
+			Help: "Number of reverse geocode lookups that missed the cache and went to the geocoding provider.",
+		}),
+
+		GeocodeFailures: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_geocode_failures_total",
+			Help: "Number of reverse geocode lookups that failed.",
+		}),
+
+		ReconnectAttempts: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_reconnect_attempts_total",
+			Help: "Number of WebSocket connection attempts, including the initial one.",
+		}),
+
+		WSFailovers: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_ws_failovers_total",
+			Help: "Number of times the client rotated to the next configured Blitzortung server after repeated connection failures.",
+		}),
+
+		SinkEmitFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "airscraper_sink_emit_failures_total",
+			Help: "Number of sink Emit calls that returned an error, by sink.",
+		}, []string{"sink"}),
+
+		FilteredBeforeGeocode: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_filtered_before_geocode_total",
+			Help: "Number of strikes dropped by the area-of-interest filter before reverse geocoding.",
+		}),
+
+		FilteredAfterGeocode: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "airscraper_filtered_after_geocode_total",
+			Help: "Number of strikes dropped by the country allow/deny filter after reverse geocoding.",
+		}),
+
+		logger: log.New(os.Stdout, "[Metrics] ", log.LstdFlags),
+	}
+}
+
+// ListenAndServe starts the /metrics HTTP listener on addr.
+func (m *Metrics) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	m.logger.Printf("Listening on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RecorderSink writes each strike, rendered through one Emitter per
+// configured format, into rotating files under a directory - the backbone
+// of running airscraper as a data recorder feeding other tools. A write
+// error, such as a full disk, fails the Emit call (and is logged/counted by
+// SinkFan like any other sink error). Writes are synchronous, so a slow disk
+// delays the sinks SinkFan calls after this one.
+type RecorderSink struct {
+	mu       sync.Mutex
+	emitters []Emitter
+	writers  map[string]*rotatingWriter
+}
+
+// NewRecorderSink opens a rotatingWriter per format in config.Formats under
+// config.LogDir, creating the directory if necessary.
+func NewRecorderSink(config RecorderConfig) (*RecorderSink, error) {
+	if len(config.Formats) == 0 {
+		return nil, fmt.Errorf("recorder requires at least one output format")
+	}
+
+	if err := os.MkdirAll(config.LogDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recorder log dir: %w", err)
+	}
+
+	sink := &RecorderSink{writers: make(map[string]*rotatingWriter, len(config.Formats))}
+
+	for _, format := range config.Formats {
+		if _, exists := sink.writers[format]; exists {
+			continue
+		}
+
+		emitter, err := NewEmitter(format)
+		if err != nil {
+			sink.Close()
+			return nil, err
+		}
+
+		writer, err := newRotatingWriter(config.LogDir, emitter.Name(), config.RotateBytes, config.Gzip)
+		if err != nil {
+			sink.Close()
+			return nil, fmt.Errorf("failed to open recorder writer for %s: %w", emitter.Name(), err)
+		}
+
+		sink.emitters = append(sink.emitters, emitter)
+		sink.writers[emitter.Name()] = writer
+	}
+
+	return sink, nil
+}
+
+func (s *RecorderSink) Name() string { return "recorder" }
+
+func (s *RecorderSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, emitter := range s.emitters {
+		record, err := emitter.Emit(strike, location)
+		if err != nil {
+			return fmt.Errorf("failed to render %s record: %w", emitter.Name(), err)
+		}
+		if _, err := s.writers[emitter.Name()].Write(record); err != nil {
+			return fmt.Errorf("failed to write %s record: %w", emitter.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes every underlying rotatingWriter, returning the first error encountered.
+func (s *RecorderSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, w := range s.writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// rotatingWriter writes one format's records to a file under dir, rolling
+// over to a new, timestamped file once rotateBytes have been written to
+// the current one (0 disables rotation), optionally gzip-compressing each
+// file as it's written.
+type rotatingWriter struct {
+	dir         string
+	ext         string
+	rotateBytes int64
+	gzipEnabled bool
+
+	file *os.File
+	gz   *gzip.Writer
+	// out is file, or gz when gzipEnabled: whichever Write should send
+	// bytes through so they end up compressed when appropriate.
+	out     io.Writer
+	written int64
+}
+
+// newRotatingWriter opens the first file for ext under dir.
+func newRotatingWriter(dir, ext string, rotateBytes int64, gzipEnabled bool) (*rotatingWriter, error) {
+	w := &rotatingWriter{dir: dir, ext: ext, rotateBytes: rotateBytes, gzipEnabled: gzipEnabled}
+	if err := w.roll(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// roll closes the current file, if any, and opens a new, timestamped one.
+func (w *rotatingWriter) roll() error {
+	if err := w.closeCurrent(); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("strikes-%s.%s", time.Now().UTC().Format("20060102T150405.000000000"), w.ext)
+	if w.gzipEnabled {
+		name += ".gz"
+	}
+
+	file, err := os.OpenFile(filepath.Join(w.dir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open recorder file: %w", err)
+	}
+
+	w.file = file
+	w.written = 0
+	if w.gzipEnabled {
+		w.gz = gzip.NewWriter(file)
+		w.out = w.gz
+	} else {
+		w.out = file
+	}
+
+	if w.ext == "csv" {
+		if err := w.writeCSVHeader(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCSVHeader writes csvHeader through w.out (rather than Write) so it
+// lands ahead of the first record without counting against rotateBytes.
+func (w *rotatingWriter) writeCSVHeader() error {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+func (w *rotatingWriter) closeCurrent() error {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			w.file.Close()
+			return fmt.Errorf("failed to close gzip writer: %w", err)
+		}
+		w.gz = nil
+	}
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to close recorder file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.rotateBytes > 0 && w.written >= w.rotateBytes {
+		if err := w.roll(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.out.Write(p)
+	w.written += int64(n)
+
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	return w.closeCurrent()
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/nats-io/nats.go"
+)
+
+// Sink receives enriched lightning strikes for downstream delivery.
+type Sink interface {
+	// Name identifies the sink for logging and metrics.
+	Name() string
+	Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error
+}
+
+// sinkRecord is the JSON shape shared by the file and webhook sinks.
+type sinkRecord struct {
+	Strike   LightningStrike    `json:"strike"`
+	Location *NominatimResponse `json:"location,omitempty"`
+}
+
+// SinkFan fans each strike out to every registered sink. An error from one
+// sink is logged and counted and does not stop delivery to the rest. Sinks
+// are called one after another in the caller's goroutine, so a sink that is
+// slow to return delays the sinks after it.
+type SinkFan struct {
+	sinks   []Sink
+	logger  *log.Logger
+	metrics *Metrics
+}
+
+// NewSinkFan builds a fan-out over the given sinks.
+func NewSinkFan(logger *log.Logger, metrics *Metrics, sinks ...Sink) *SinkFan {
+	return &SinkFan{sinks: sinks, logger: logger, metrics: metrics}
+}
+
+// Emit delivers the strike to every registered sink.
+func (f *SinkFan) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) {
+	for _, sink := range f.sinks {
+		if err := sink.Emit(ctx, strike, location); err != nil {
+			f.logger.Printf("Sink %s emit failed: %v", sink.Name(), err)
+			f.metrics.SinkEmitFailures.WithLabelValues(sink.Name()).Inc()
+		}
+	}
+}
+
+// Close closes every registered sink that implements io.Closer, logging
+// (rather than failing on) any error so one sink can't stop the rest from
+// closing cleanly. This matters beyond freeing resources: sinks that buffer
+// writes, like the gzip-compressing RecorderSink, produce truncated output
+// unless closed.
+func (f *SinkFan) Close() {
+	for _, sink := range f.sinks {
+		closer, ok := sink.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			f.logger.Printf("Sink %s close failed: %v", sink.Name(), err)
+		}
+	}
+}
+
+// TerminalSink pretty-prints strikes to stdout via DisplayStrike.
+type TerminalSink struct{}
+
+func (TerminalSink) Name() string { return "terminal" }
+
+func (TerminalSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	DisplayStrike(strike, location)
+	return nil
+}
+
+// FileSink appends one NDJSON record per strike to a file.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens (creating if necessary) path for append-only NDJSON writes.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+func (s *FileSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	line, err := json.Marshal(sinkRecord{Strike: strike, Location: location})
+	if err != nil {
+		return fmt.Errorf("failed to marshal strike: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write strike: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// MQTTSink publishes each strike to a per-country topic, e.g. lightning/us.
+type MQTTSink struct {
+	client mqtt.Client
+}
+
+// NewMQTTSink connects to broker and returns a ready-to-use sink.
+func NewMQTTSink(broker string) (*MQTTSink, error) {
+	opts := mqtt.NewClientOptions().AddBroker(broker)
+	client := mqtt.NewClient(opts)
+
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", broker, token.Error())
+	}
+
+	return &MQTTSink{client: client}, nil
+}
+
+func (s *MQTTSink) Name() string { return "mqtt" }
+
+func (s *MQTTSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	payload, err := json.Marshal(strike)
+	if err != nil {
+		return fmt.Errorf("failed to marshal strike: %w", err)
+	}
+
+	topic := fmt.Sprintf("lightning/%s", countryCodeOrUnknown(location))
+	token := s.client.Publish(topic, 0, false, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// NATSSink publishes each strike as a JSON message to a single NATS subject.
+type NATSSink struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// NewNATSSink connects to url and returns a ready-to-use sink publishing on subject.
+func NewNATSSink(url, subject string) (*NATSSink, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", url, err)
+	}
+
+	return &NATSSink{conn: conn, subject: subject}, nil
+}
+
+func (s *NATSSink) Name() string { return "nats" }
+
+func (s *NATSSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	payload, err := json.Marshal(strike)
+	if err != nil {
+		return fmt.Errorf("failed to marshal strike: %w", err)
+	}
+
+	if err := s.conn.Publish(s.subject, payload); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookSink POSTs each strike as JSON to a configured URL.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink that POSTs to url.
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Emit(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	body, err := json.Marshal(sinkRecord{Strike: strike, Location: location})
+	if err != nil {
+		return fmt.Errorf("failed to marshal strike: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func countryCodeOrUnknown(location *NominatimResponse) string {
+	if location != nil && location.Address.CountryCode != "" {
+		return location.Address.CountryCode
+	}
+	return "unknown"
+}
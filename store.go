@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store persists lightning strikes and reverse-geocode results, and serves
+// them back out for the HTTP query endpoint. sqliteStore and postgresStore
+// are the concrete backends, selected via Config.DBType.
+type Store interface {
+	// Insert persists a decoded strike along with its resolved location, if any.
+	Insert(ctx context.Context, strike LightningStrike, location *NominatimResponse) error
+	// Query returns strikes matching filter, most recent last.
+	Query(ctx context.Context, filter StrikeFilter) ([]StoredStrike, error)
+
+	Close() error
+}
+
+// StrikeFilter narrows a Query call. The zero value matches every strike.
+type StrikeFilter struct {
+	Since time.Time
+	BBox  *boundingBox
+	// MaxStatus, if non-nil, excludes strikes worse than this status code.
+	// Status codes run 0 (Very good) upward to worse quality, so this is a
+	// ceiling on the code, i.e. a floor on quality.
+	MaxStatus *int
+}
+
+// StoredStrike is a strike as read back from a Store, with its resolved
+// location attached.
+type StoredStrike struct {
+	LightningStrike
+	Location *NominatimResponse `json:"location,omitempty"`
+}
+
+// NewStoreFromConfig opens the storage backend selected by config.DBType
+// ("sqlite" by default, or "postgres"), migrating it if necessary.
+func NewStoreFromConfig(config *Config) (Store, error) {
+	switch config.DBType {
+	case "", "sqlite":
+		return newSQLiteStore(config.DBConnection)
+	case "postgres":
+		return newPostgresStore(config.DBConnection)
+	default:
+		return nil, fmt.Errorf("unsupported db_type %q (want sqlite or postgres)", config.DBType)
+	}
+}
+
+// sqliteStore is the Store implementation backed by SQLite.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// newSQLiteStore opens (creating and migrating if necessary) the SQLite
+// database at path.
+func newSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite store: %w", err)
+	}
+
+	store := &sqliteStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate sqlite store: %w", err)
+	}
+
+	return store, nil
+}
+
+func (s *sqliteStore) migrate() error {
+	_, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS strikes (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	time         INTEGER NOT NULL,
+	lat          REAL NOT NULL,
+	lon          REAL NOT NULL,
+	alt          INTEGER NOT NULL,
+	polarity     INTEGER NOT NULL,
+	mcg          INTEGER NOT NULL,
+	mds          INTEGER NOT NULL,
+	region       INTEGER NOT NULL,
+	status       INTEGER NOT NULL,
+	country_code TEXT,
+	state        TEXT,
+	city         TEXT,
+	road         TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_strikes_time ON strikes(time);
+CREATE INDEX IF NOT EXISTS idx_strikes_lat_lon ON strikes(lat, lon);
+CREATE INDEX IF NOT EXISTS idx_strikes_country_code ON strikes(country_code);
+
+CREATE TABLE IF NOT EXISTS signals (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	strike_id INTEGER NOT NULL REFERENCES strikes(id),
+	time      INTEGER NOT NULL,
+	lat       REAL NOT NULL,
+	lon       REAL NOT NULL,
+	alt       INTEGER NOT NULL,
+	sta       INTEGER NOT NULL,
+	status    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_signals_strike_id ON signals(strike_id);
+`)
+	return err
+}
+
+// Close closes the underlying database connection.
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// Insert persists a decoded strike along with its resolved location, if any.
+// The strike row and its signal rows are written in a single transaction so
+// a failure partway through never leaves a strike with partial signals.
+func (s *sqliteStore) Insert(ctx context.Context, strike LightningStrike, location *NominatimResponse) error {
+	var countryCode, state, city, road string
+	if location != nil {
+		countryCode = location.Address.CountryCode
+		state = location.Address.State
+		city = location.Address.City
+		road = location.Address.Road
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO strikes (time, lat, lon, alt, polarity, mcg, mds, region, status, country_code, state, city, road)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		strike.Time, strike.Lat, strike.Lon, strike.Alt, strike.Pol, strike.MCG, strike.MDS,
+		strike.Region, strike.Status, countryCode, state, city, road,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert strike: %w", err)
+	}
+
+	strikeID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read inserted strike id: %w", err)
+	}
+
+	for _, sig := range strike.Sig {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO signals (strike_id, time, lat, lon, alt, sta, status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			strikeID, sig.Time, sig.Lat, sig.Lon, sig.Alt, sig.Sta, sig.Status,
+		); err != nil {
+			return fmt.Errorf("failed to insert signal: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit strike: %w", err)
+	}
+
+	return nil
+}
+
+// Query returns strikes matching filter, most recent last, with their
+// signals and resolved location attached.
+func (s *sqliteStore) Query(ctx context.Context, filter StrikeFilter) ([]StoredStrike, error) {
+	query := `SELECT id, time, lat, lon, alt, polarity, mcg, mds, region, status, country_code, state, city, road
+	          FROM strikes WHERE time >= ?`
+	args := []interface{}{filter.Since.UnixNano()}
+
+	if filter.MaxStatus != nil {
+		query += ` AND status <= ?`
+		args = append(args, *filter.MaxStatus)
+	}
+
+	if filter.BBox != nil {
+		query += ` AND lat >= ? AND lat <= ? AND lon >= ? AND lon <= ?`
+		args = append(args, filter.BBox.minLat, filter.BBox.maxLat, filter.BBox.minLon, filter.BBox.maxLon)
+	}
+	query += ` ORDER BY time`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query strikes: %w", err)
+	}
+	defer rows.Close()
+
+	var strikes []StoredStrike
+	ids := make([]int64, 0)
+	byID := make(map[int64]*StoredStrike)
+
+	for rows.Next() {
+		var id int64
+		var strike StoredStrike
+		var countryCode, state, city, road sql.NullString
+		if err := rows.Scan(&id, &strike.Time, &strike.Lat, &strike.Lon, &strike.Alt, &strike.Pol,
+			&strike.MCG, &strike.MDS, &strike.Region, &strike.Status, &countryCode, &state, &city, &road); err != nil {
+			return nil, fmt.Errorf("failed to scan strike: %w", err)
+		}
+		if countryCode.Valid && countryCode.String != "" {
+			strike.Location = &NominatimResponse{}
+			strike.Location.Address.CountryCode = countryCode.String
+			strike.Location.Address.State = state.String
+			strike.Location.Address.City = city.String
+			strike.Location.Address.Road = road.String
+		}
+		strikes = append(strikes, strike)
+		ids = append(ids, id)
+		byID[id] = &strikes[len(strikes)-1]
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachSignals(ctx, ids, byID); err != nil {
+		return nil, err
+	}
+
+	return strikes, nil
+}
+
+// attachSignals fetches the signals for ids and attaches them to the
+// matching entry in byID.
+func (s *sqliteStore) attachSignals(ctx context.Context, ids []int64, byID map[int64]*StoredStrike) error {
+	for _, id := range ids {
+		rows, err := s.db.QueryContext(ctx,
+			`SELECT time, lat, lon, alt, sta, status FROM signals WHERE strike_id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to query signals: %w", err)
+		}
+
+		var signals []Signal
+		for rows.Next() {
+			var sig Signal
+			if err := rows.Scan(&sig.Time, &sig.Lat, &sig.Lon, &sig.Alt, &sig.Sta, &sig.Status); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan signal: %w", err)
+			}
+			signals = append(signals, sig)
+		}
+		closeErr := rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+
+		byID[id].Sig = signals
+	}
+
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,39 +20,132 @@ type WSClient struct {
 	logger    *log.Logger
 	geocoding *GeocodingService
 	decoder   *LZWDecoder
+	store     Store
+	sinks     *SinkFan
+	metrics   *Metrics
+	filter    *Filter
+
+	stateMu      sync.RWMutex
+	connected    bool
+	lastStrikeAt time.Time
+	urlIndex     int
 }
 
-// Creates a new WebSocket client
-func NewWSClient(config *Config, geocoding *GeocodingService, decoder *LZWDecoder) *WSClient {
+// Creates a new WebSocket client. store may be nil, in which case strikes
+// are delivered to the sinks but not persisted. filter may be nil, in which case every
+// strike passes through.
+func NewWSClient(config *Config, geocoding *GeocodingService, decoder *LZWDecoder, store Store, sinks *SinkFan, metrics *Metrics, filter *Filter) *WSClient {
 	return &WSClient{
 		config:    config,
 		logger:    log.New(os.Stdout, "[WSClient] ", log.LstdFlags),
 		geocoding: geocoding,
 		decoder:   decoder,
+		store:     store,
+		sinks:     sinks,
+		metrics:   metrics,
+		filter:    filter,
 	}
 }
 
+// Connected reports whether the WebSocket is currently connected.
+func (ws *WSClient) Connected() bool {
+	ws.stateMu.RLock()
+	defer ws.stateMu.RUnlock()
+	return ws.connected
+}
+
+// LastStrikeAt returns the time the most recent strike was processed.
+func (ws *WSClient) LastStrikeAt() time.Time {
+	ws.stateMu.RLock()
+	defer ws.stateMu.RUnlock()
+	return ws.lastStrikeAt
+}
+
+func (ws *WSClient) setConnected(connected bool) {
+	ws.stateMu.Lock()
+	ws.connected = connected
+	ws.stateMu.Unlock()
+}
+
+// CurrentURL returns the Blitzortung server Connect will dial next.
+func (ws *WSClient) CurrentURL() string {
+	ws.stateMu.RLock()
+	defer ws.stateMu.RUnlock()
+	return ws.config.URLs[ws.urlIndex%len(ws.config.URLs)]
+}
+
+// RotateURL advances to the next configured server, wrapping around to the
+// first once the last one is reached.
+func (ws *WSClient) RotateURL() string {
+	ws.stateMu.Lock()
+	ws.urlIndex = (ws.urlIndex + 1) % len(ws.config.URLs)
+	next := ws.config.URLs[ws.urlIndex]
+	ws.stateMu.Unlock()
+	return next
+}
+
 // Establishes a WebSocket connection
 func (ws *WSClient) Connect() error {
-	ws.logger.Printf("Connecting to %s...", ws.config.URL)
+	url := ws.CurrentURL()
+	ws.logger.Printf("Connecting to %s...", url)
 
 	dialer := &websocket.Dialer{
 		HandshakeTimeout: ws.config.HandshakeTimeout,
 	}
 
-	conn, _, err := dialer.Dial(ws.config.URL, nil)
+	conn, _, err := dialer.Dial(url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to connect to WebSocket: %w", err)
 	}
 
+	// Respond to server-initiated pings and treat them as liveness signals.
+	conn.SetPingHandler(func(appData string) error {
+		conn.SetReadDeadline(time.Now().Add(ws.config.ReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(ws.config.WriteTimeout))
+	})
+
+	// A pong in response to our own keepalive ping is also a liveness signal.
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(ws.config.ReadTimeout))
+		return nil
+	})
+
 	ws.conn = conn
-	ws.logger.Printf("Connection established with %s", ws.config.URL)
+	ws.setConnected(true)
+	ws.metrics.WSConnected.Set(1)
+	ws.logger.Printf("Connection established with %s", url)
 
 	return nil
 }
 
+// pingLoop sends periodic keepalive pings so a dead connection is detected
+// promptly instead of waiting for ReadTimeout to expire on its own.
+func (ws *WSClient) pingLoop(ctx context.Context) {
+	ticker := time.NewTicker(ws.config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if ws.conn == nil {
+				continue
+			}
+			deadline := time.Now().Add(ws.config.WriteTimeout)
+			if err := ws.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				ws.logger.Printf("Failed to send keepalive ping: %v", err)
+				return
+			}
+		}
+	}
+}
+
 // Closes the WebSocket connection
 func (ws *WSClient) Close() error {
+	ws.setConnected(false)
+	ws.metrics.WSConnected.Set(0)
+
 	if ws.conn == nil {
 		return nil
 	}
@@ -116,7 +211,7 @@ func (ws *WSClient) ReadMessages(ctx context.Context) error {
 				return nil
 			}
 
-			if err := ws.processMessage(message); err != nil {
+			if err := ws.processMessage(ctx, message); err != nil {
 				ws.logger.Printf("Error processing message: %v", err)
 				continue
 			}
@@ -125,7 +220,10 @@ func (ws *WSClient) ReadMessages(ctx context.Context) error {
 }
 
 // Processes a received message
-func (ws *WSClient) processMessage(message []byte) error {
+func (ws *WSClient) processMessage(ctx context.Context, message []byte) error {
+	start := time.Now()
+	defer func() { ws.metrics.ProcessingDuration.Observe(time.Since(start).Seconds()) }()
+
 	decodedMessage, err := ws.decoder.Decode(message)
 	if err != nil {
 		return fmt.Errorf("failed to decode LZW: %w", err)
@@ -137,14 +235,49 @@ func (ws *WSClient) processMessage(message []byte) error {
 		return err
 	}
 
+	if !ws.filter.AllowBeforeGeocode(strike.Lat, strike.Lon) {
+		ws.metrics.FilteredBeforeGeocode.Inc()
+		return nil
+	}
+
 	// Get location information
-	location, err := ws.geocoding.ReverseGeocodeWithRateLimit(strike.Lat, strike.Lon)
+	location, err := ws.geocoding.ReverseGeocodeWithRateLimit(ctx, strike.Lat, strike.Lon)
 	if err != nil {
 		ws.logger.Printf("Failed to get location for strike: %v", err)
 		// Continue with displaying the strike even if geocoding fails!
 	}
 
-	DisplayStrike(strike, location)
+	if !ws.filter.AllowAfterGeocode(location) {
+		ws.metrics.FilteredAfterGeocode.Inc()
+		return nil
+	}
+
+	if ws.store != nil {
+		if err := ws.store.Insert(ctx, strike, location); err != nil {
+			ws.logger.Printf("Failed to persist strike: %v", err)
+		}
+	}
+
+	now := time.Now()
+	ws.stateMu.Lock()
+	ws.lastStrikeAt = now
+	ws.stateMu.Unlock()
+
+	ws.metrics.StrikesTotal.WithLabelValues(
+		countryCodeOrUnknown(location), polarityLabel(strike.Pol), strconv.Itoa(strike.Status),
+	).Inc()
+	ws.metrics.StrikeDelay.Observe(strike.Delay)
+	ws.metrics.StrikeMCG.Observe(float64(strike.MCG))
+	ws.metrics.LastStrikeTime.Set(float64(now.Unix()))
+
+	ws.sinks.Emit(ctx, strike, location)
 
 	return nil
 }
+
+func polarityLabel(pol int) string {
+	if pol == 0 {
+		return "negative"
+	}
+	return "positive"
+}